@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EncodedConversation 是 ConversationEncoder 编码后的产物。不同编码器只填充
+// 自己产出的字段，调用方按上游接口形状读取对应字段。
+type EncodedConversation struct {
+	Messages         []Message // 原生 OpenAI messages 透传
+	PreviousQuestion string    // heck 旧式单轮 previousQuestion/previousAnswer 形状
+	PreviousAnswer   string
+	RenderedPrompt   string // 把整段历史渲染成一个字符串，塞进只接受单个 question 字段的上游
+}
+
+// ConversationEncoder 把（已做过 token 预算截断的）messages 编码成某个上游
+// 接口期望的形状。
+type ConversationEncoder interface {
+	Encode(messages []Message) EncodedConversation
+}
+
+// NativeMessagesEncoder 原样透传 messages 数组，适用于本身就支持 OpenAI
+// messages 形状的上游（ddg、openai 透传）。
+type NativeMessagesEncoder struct{}
+
+func (NativeMessagesEncoder) Encode(messages []Message) EncodedConversation {
+	return EncodedConversation{Messages: messages}
+}
+
+// HeckPairEncoder 复刻 heck 网关旧版只认 previousQuestion/previousAnswer
+// 单轮历史的形状，保留下来供需要兼容旧行为的部署选用。
+type HeckPairEncoder struct{}
+
+func (HeckPairEncoder) Encode(messages []Message) EncodedConversation {
+	var previousQuestion, previousAnswer string
+	n := len(messages)
+	if n >= 2 {
+		for i := n - 2; i >= 0; i-- {
+			if messages[i].Role == "user" {
+				previousQuestion = messages[i].Content
+				if i+1 < n && messages[i+1].Role == "assistant" {
+					previousAnswer = messages[i+1].Content
+				}
+				break
+			}
+		}
+	}
+	return EncodedConversation{PreviousQuestion: previousQuestion, PreviousAnswer: previousAnswer}
+}
+
+// RenderedPromptEncoder 把完整历史按角色拼接成一段文本，可以塞进只接受单个
+// question 字段的上游接口，从而在不改上游协议的前提下转发完整对话。
+// 各角色的模板可以通过环境变量覆盖。
+type RenderedPromptEncoder struct {
+	SystemTemplate    string
+	UserTemplate      string
+	AssistantTemplate string
+}
+
+// NewRenderedPromptEncoder 按 PROMPT_TEMPLATE_* 环境变量构造编码器，未设置时使用默认模板
+func NewRenderedPromptEncoder() RenderedPromptEncoder {
+	return RenderedPromptEncoder{
+		SystemTemplate:    envOr("PROMPT_TEMPLATE_SYSTEM", "[SYSTEM]\n%s\n"),
+		UserTemplate:      envOr("PROMPT_TEMPLATE_USER", "[USER]\n%s\n"),
+		AssistantTemplate: envOr("PROMPT_TEMPLATE_ASSISTANT", "[ASSISTANT]\n%s\n"),
+	}
+}
+
+func (e RenderedPromptEncoder) Encode(messages []Message) EncodedConversation {
+	var b strings.Builder
+	for _, m := range messages {
+		switch m.Role {
+		case "system":
+			fmt.Fprintf(&b, e.SystemTemplate, m.Content)
+		case "assistant":
+			fmt.Fprintf(&b, e.AssistantTemplate, m.Content)
+		default:
+			fmt.Fprintf(&b, e.UserTemplate, m.Content)
+		}
+	}
+	return EncodedConversation{RenderedPrompt: b.String()}
+}
+
+// estimateTokens 在没有真正 BPE 分词器的情况下做一个保底估算：约每 4 个
+// 字符算 1 个 token，这是 OpenAI 文档给出的英文经验值；中文一个字通常就接近
+// 1 个 token，按字符数除以 4 会明显低估中文的 token 数。
+func estimateTokens(s string) int {
+	return (len([]rune(s)) + 3) / 4
+}
+
+// truncateMessages 保留开头的 system 消息（如果有），外加从最新往前数、
+// token 预算允许的尽量多的消息，避免长对话把上游上下文长度打爆。
+// maxTokens <= 0 表示不限制。
+func truncateMessages(messages []Message, maxTokens int) []Message {
+	if maxTokens <= 0 || len(messages) == 0 {
+		return messages
+	}
+
+	rest := messages
+	var system *Message
+	if messages[0].Role == "system" {
+		system = &messages[0]
+		rest = messages[1:]
+	}
+
+	budget := maxTokens
+	if system != nil {
+		budget -= estimateTokens(system.Content)
+	}
+
+	kept := make([]Message, 0, len(rest))
+	for i := len(rest) - 1; i >= 0; i-- {
+		cost := estimateTokens(rest[i].Content)
+		if cost > budget && len(kept) > 0 {
+			break
+		}
+		budget -= cost
+		kept = append(kept, rest[i])
+	}
+	for l, r := 0, len(kept)-1; l < r; l, r = l+1, r-1 {
+		kept[l], kept[r] = kept[r], kept[l]
+	}
+
+	if system != nil {
+		return append([]Message{*system}, kept...)
+	}
+	return kept
+}
+
+// conversationTokenBudget 通过 CONVERSATION_TOKEN_BUDGET 配置发给上游前保留的
+// token 预算，默认 4000
+var conversationTokenBudget = envInt("CONVERSATION_TOKEN_BUDGET", 4000)
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func envInt(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"bufio"
+	"sync/atomic"
+	"time"
+)
+
+// streamIdleTimeout 是流式响应里相邻两行（任意行，不只是 "data:" 行）之间允许的最大间隔，超过就认为
+// 上游卡死，主动断开。可通过 STREAM_IDLE_TIMEOUT_SECONDS 配置，默认 30 秒。
+var streamIdleTimeout = time.Duration(envInt("STREAM_IDLE_TIMEOUT_SECONDS", 30)) * time.Second
+
+// streamMaxDuration 是单次请求允许的最长总时长，防止上游挂住的连接无限占用。
+// 可通过 STREAM_MAX_DURATION_SECONDS 配置，默认 120 秒。
+var streamMaxDuration = time.Duration(envInt("STREAM_MAX_DURATION_SECONDS", 120)) * time.Second
+
+// deadlineReader 包一层 bufio.Reader，用 time.AfterFunc 实现“多久没收到新的一行
+// 就断开”的空闲超时：每次成功读取就把计时器重置回 idle；计时器触发时调用
+// closeBody（通常是 resp.Body.Close()），促使阻塞中的 ReadString 带错误返回，
+// 读取循环才能退出。
+type deadlineReader struct {
+	r         *bufio.Reader
+	timer     *time.Timer
+	idle      time.Duration
+	closeBody func()
+	timedOut  int32
+}
+
+// newDeadlineReader 构造一个 deadlineReader 并立刻启动第一轮空闲计时
+func newDeadlineReader(r *bufio.Reader, idle time.Duration, closeBody func()) *deadlineReader {
+	d := &deadlineReader{r: r, idle: idle, closeBody: closeBody}
+	d.timer = time.AfterFunc(idle, d.onIdle)
+	return d
+}
+
+func (d *deadlineReader) onIdle() {
+	atomic.StoreInt32(&d.timedOut, 1)
+	d.closeBody()
+}
+
+// ReadString 代理 bufio.Reader.ReadString，每次成功读取后重置空闲计时器
+func (d *deadlineReader) ReadString(delim byte) (string, error) {
+	line, err := d.r.ReadString(delim)
+	if err == nil {
+		d.timer.Reset(d.idle)
+	}
+	return line, err
+}
+
+// TimedOut 报告读取循环退出是否是因为空闲超时触发而不是上游正常结束
+func (d *deadlineReader) TimedOut() bool {
+	return atomic.LoadInt32(&d.timedOut) == 1
+}
+
+// Stop 停掉计时器，读取循环结束时调用，避免计时器之后再触发 onIdle
+func (d *deadlineReader) Stop() {
+	d.timer.Stop()
+}
@@ -0,0 +1,40 @@
+package handler
+
+// StreamEventKind 对上游一行 SSE 内容做统一分类。各 Provider 自己的协议细节
+// （heck 的 [REASONING_*]/[ANSWER_*] 标记、OpenAI 兼容上游和 ddg 的
+// data: {json}/[DONE] 帧）都封装在各自的 ResponseDecoder 实现里，main.go 里
+// 的 handleStreamResponse/handleNormalResponse 只认这几种分类，不再关心具体
+// 协议的标记字符串。
+type StreamEventKind int
+
+const (
+	// EventIgnore 这一行不产生任何输出（心跳、无法识别的行等）
+	EventIgnore StreamEventKind = iota
+	// EventReasoningStart 标志思维链开始
+	EventReasoningStart
+	// EventReasoningDelta 携带一段思维链增量内容，内容放在 Content 里
+	EventReasoningDelta
+	// EventReasoningDone 标志思维链结束
+	EventReasoningDone
+	// EventAnswerStart 标志正式回答开始
+	EventAnswerStart
+	// EventAnswerDelta 携带一段回答增量内容，内容放在 Content 里
+	EventAnswerDelta
+	// EventDone 标志整个流正常结束
+	EventDone
+)
+
+// StreamEvent 是 ResponseDecoder.Decode 的返回值；Content 只在 *Delta 类事件
+// 里有意义。
+type StreamEvent struct {
+	Kind    StreamEventKind
+	Content string
+}
+
+// ResponseDecoder 把某个 Provider 上游一行已经去掉 "data: " 前缀的 SSE 内容
+// 解析成统一的 StreamEvent。解码器通常是有状态的（比如 heck 需要记住当前是
+// 不是在 reasoning/answering 阶段），由 Provider.NewDecoder 在每次请求开始时
+// 新建一个，不能跨请求复用。
+type ResponseDecoder interface {
+	Decode(content string) StreamEvent
+}
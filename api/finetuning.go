@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FineTuningJob 按官方 fine_tuning.job 的 JSON schema 返回。本项目不提供真正
+// 的微调能力，任务一创建就转入 failed，但字段形状要跟官方一致，SDK 的响应
+// 校验才能通过，调用方才能拿到明确的错误而不是反序列化失败。
+type FineTuningJob struct {
+	ID              string           `json:"id"`
+	Object          string           `json:"object"`
+	Model           string           `json:"model"`
+	Status          string           `json:"status"`
+	CreatedAt       int64            `json:"created_at"`
+	FinishedAt      int64            `json:"finished_at"`
+	TrainingFile    string           `json:"training_file"`
+	Hyperparameters Hyperparameters  `json:"hyperparameters"`
+	Error           *FineTuningError `json:"error"`
+}
+
+// Hyperparameters 镜像官方 schema 里 n_epochs 的 number-or-"auto" 联合类型
+type Hyperparameters struct {
+	NEpochs NEpochs `json:"n_epochs"`
+}
+
+// NEpochs 要么是具体轮数，要么是字符串 "auto"；这个部署只会产出 "auto"，但
+// 序列化形状要跟官方一致，SDK 按联合类型校验时才不会出错
+type NEpochs struct {
+	Auto bool
+	N    int
+}
+
+func (e NEpochs) MarshalJSON() ([]byte, error) {
+	if e.Auto {
+		return json.Marshal("auto")
+	}
+	return json.Marshal(e.N)
+}
+
+// FineTuningError 是 FineTuningJob 失败时携带的错误详情
+type FineTuningError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type fineTuningJobList struct {
+	Object string          `json:"object"`
+	Data   []FineTuningJob `json:"data"`
+}
+
+type fineTuningJobEvent struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	CreatedAt int64  `json:"created_at"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+}
+
+type fineTuningEventList struct {
+	Object string               `json:"object"`
+	Data   []fineTuningJobEvent `json:"data"`
+}
+
+// notImplementedJob 构造一个“提交即失败”的任务：字段形状合法，但明确告诉
+// 调用方这个部署不提供真正的微调能力。
+func notImplementedJob(id, model, trainingFile string) FineTuningJob {
+	now := time.Now().Unix()
+	return FineTuningJob{
+		ID:              id,
+		Object:          "fine_tuning.job",
+		Model:           model,
+		Status:          "failed",
+		CreatedAt:       now,
+		FinishedAt:      now,
+		TrainingFile:    trainingFile,
+		Hyperparameters: Hyperparameters{NEpochs: NEpochs{Auto: true}},
+		Error: &FineTuningError{
+			Code:    "not_implemented",
+			Message: "fine-tuning is not supported by this deployment",
+		},
+	}
+}
+
+// handleFineTuning 路由 /v1/fine_tuning/jobs 及其子路径，只提供满足 SDK 校验
+// 所需的最小 Create/List/Retrieve/Cancel/ListEvents 行为。
+func handleFineTuning(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	idx := strings.Index(r.URL.Path, "/v1/fine_tuning/jobs")
+	sub := strings.Trim(strings.TrimPrefix(r.URL.Path[idx:], "/v1/fine_tuning/jobs"), "/")
+
+	switch {
+	case sub == "" && r.Method == http.MethodPost:
+		var body struct {
+			Model        string `json:"model"`
+			TrainingFile string `json:"training_file"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(notImplementedJob(uuid.New().String(), body.Model, body.TrainingFile))
+
+	case sub == "" && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(fineTuningJobList{Object: "list", Data: []FineTuningJob{}})
+
+	case strings.HasSuffix(sub, "/cancel") && r.Method == http.MethodPost:
+		job := notImplementedJob(strings.TrimSuffix(sub, "/cancel"), "", "")
+		job.Status = "cancelled"
+		json.NewEncoder(w).Encode(job)
+
+	case strings.HasSuffix(sub, "/events") && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(fineTuningEventList{Object: "list", Data: []fineTuningJobEvent{}})
+
+	case sub != "" && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(notImplementedJob(sub, "", ""))
+
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
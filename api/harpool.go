@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HarEntry 是从 harPool/ 目录下某个 .har 抓包文件里提取出的一份可复用请求模板，
+// 只保留握手所需的请求头（Cookie、x-vqd-4 等）。连续失败次数超限会被冷却
+// harCooldown，握手成功一次就清零失败计数；抓包里的 Cookie/x-vqd-4 本身也会
+// 过期，加载超过 harEntryTTL 之后这份模板会被永久跳过，不再参与轮询。
+type HarEntry struct {
+	File    string
+	Headers map[string]string
+
+	mu            sync.Mutex
+	fails         int32
+	loadedAt      time.Time
+	cooldownUntil time.Time
+}
+
+// harLog 只解析标准 HAR 格式里我们关心的那一小部分字段
+type harLog struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// harHeadersOfInterest 是握手时真正需要从抓包里复用的请求头
+var harHeadersOfInterest = map[string]bool{
+	"cookie":        true,
+	"x-vqd-4":       true,
+	"x-vqd-hash-1":  true,
+	"authorization": true,
+}
+
+const (
+	maxHarFailures = 3
+	harCooldown    = 2 * time.Minute
+	harEntryTTL    = 30 * time.Minute
+)
+
+// HarPool 在多份抓包模板间轮询，自动跳过失败次数过多的条目
+type HarPool struct {
+	mu      sync.Mutex
+	entries []*HarEntry
+	next    uint32
+}
+
+// LoadHarPool 扫描 dir 下的所有 .har 文件，解析出每个请求里的关键请求头。
+// 目录不存在或没有可用文件时返回一个空池，调用方应退化为不带握手头的直连。
+func LoadHarPool(dir string) (*HarPool, error) {
+	pool := &HarPool{}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.har"))
+	if err != nil {
+		return pool, err
+	}
+
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var parsed harLog
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			continue
+		}
+		if len(parsed.Log.Entries) == 0 {
+			continue
+		}
+
+		headers := map[string]string{}
+		for _, entry := range parsed.Log.Entries {
+			for _, h := range entry.Request.Headers {
+				if harHeadersOfInterest[strings.ToLower(h.Name)] {
+					headers[h.Name] = h.Value
+				}
+			}
+		}
+		if len(headers) == 0 {
+			continue
+		}
+		pool.entries = append(pool.entries, &HarEntry{File: f, Headers: headers, loadedAt: time.Now()})
+	}
+
+	return pool, nil
+}
+
+// Next 以轮询方式返回下一个存活的模板；池为空或全部被淘汰时返回 nil
+func (p *HarPool) Next() *HarEntry {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	n := len(p.entries)
+	entries := p.entries
+	p.mu.Unlock()
+
+	now := time.Now()
+	for i := 0; i < n; i++ {
+		idx := atomic.AddUint32(&p.next, 1) % uint32(n)
+		e := entries[idx]
+		if e.usable(now) {
+			return e
+		}
+	}
+	return nil
+}
+
+// usable 报告这份模板当前是否可以参与轮询：加载太久（握手头过期）永久跳过，
+// 连续失败触发的冷却期还没过也跳过
+func (e *HarEntry) usable(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if now.Sub(e.loadedAt) > harEntryTTL {
+		return false
+	}
+	return now.After(e.cooldownUntil)
+}
+
+// MarkFailure 记录一次失败，连续失败达到 maxHarFailures 次就冷却 harCooldown
+// 并清零计数，避免冷却期一过又立刻因为同一批失败再次触发
+func (e *HarEntry) MarkFailure() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fails++
+	if e.fails >= maxHarFailures {
+		e.cooldownUntil = time.Now().Add(harCooldown)
+		e.fails = 0
+	}
+}
+
+// MarkSuccess 记录一次成功的握手，清零失败计数，避免偶发失败无限累积
+func (e *HarEntry) MarkSuccess() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fails = 0
+}
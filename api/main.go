@@ -2,9 +2,10 @@ package handler
 
 import (
 	"bufio"
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
@@ -20,8 +21,9 @@ type OpenAIRequest struct {
 }
 
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role             string `json:"role"`
+	Content          string `json:"content"`
+	ReasoningContent string `json:"reasoning_content,omitempty"`
 }
 
 // UnmarshalJSON 自定义JSON解析方法
@@ -75,14 +77,6 @@ type Choice struct {
 	FinishReason string  `json:"finish_reason"`
 }
 
-var modelMapping = map[string]string{
-	"deepseek":          "deepseek/deepseek-chat",
-	"gpt-4o-mini":       "openai/gpt-4o-mini",
-	"gemini-flash-1.5":  "google/gemini-flash-1.5",
-	"deepseek-reasoner": "deepseek-reasoner",
-	"minimax-01":        "minimax/minimax-01",
-}
-
 func Handler(w http.ResponseWriter, r *http.Request) {
 	// 设置CORS头部
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -95,7 +89,17 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !strings.HasSuffix(r.URL.Path, "/v1/chat/completions") {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/debug/proxies"):
+		// 代理列表带着出口地址和最近的错误信息，鉴权检查通过之后再分发，
+		// 避免配置了 AUTH_TOKEN 时这条调试接口仍然对外公开
+	case strings.HasSuffix(r.URL.Path, "/v1/models"),
+		strings.Contains(r.URL.Path, "/v1/models/"),
+		strings.Contains(r.URL.Path, "/v1/fine_tuning/jobs"):
+		// 和 /debug/proxies 一样，鉴权检查通过之后再分发
+	case strings.HasSuffix(r.URL.Path, "/v1/chat/completions"):
+		// 继续走下面的 chat completions 逻辑
+	default:
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]string{
 			"status":  "Service Running",
@@ -116,6 +120,22 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if strings.HasSuffix(r.URL.Path, "/debug/proxies") {
+		handleDebugProxies(w, r)
+		return
+	}
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/v1/models"):
+		handleListModels(w, r)
+		return
+	case strings.Contains(r.URL.Path, "/v1/models/"):
+		handleRetrieveModel(w, r)
+		return
+	case strings.Contains(r.URL.Path, "/v1/fine_tuning/jobs"):
+		handleFineTuning(w, r)
+		return
+	}
+
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
@@ -128,8 +148,8 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	actualModel, exists := modelMapping[req.Model]
-	if !exists {
+	provider, actualModel, ok := resolveProvider(req.Model)
+	if !ok {
 		fmt.Printf("Unsupported Model: %s\n", req.Model)
 		http.Error(w, fmt.Sprintf("Unsupported Model: %s", req.Model), http.StatusBadRequest)
 		return
@@ -151,130 +171,147 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache, no-transform")
 	w.Header().Set("Connection", "keep-alive")
 
+	// 客户端断开（r.Context() 被取消）或整体超时都会中止这次上游调用
+	ctx, cancel := context.WithTimeout(r.Context(), streamMaxDuration)
+	defer cancel()
+
 	if req.Stream {
 		w.Header().Set("Content-Type", "text/event-stream")
-		handleStreamResponse(w, question, sessionID, req.Messages, req.Model, actualModel)
+		handleStreamResponse(ctx, w, provider, question, sessionID, req.Messages, req.Model, actualModel)
 	} else {
 		w.Header().Set("Content-Type", "application/json")
-		handleNormalResponse(w, question, sessionID, req.Messages, req.Model, actualModel)
+		handleNormalResponse(ctx, w, provider, question, sessionID, req.Messages, req.Model, actualModel)
 	}
 }
 
-func handleStreamResponse(w http.ResponseWriter, question, sessionID string, messages []Message, requestModel, actualModel string) {
+func handleStreamResponse(ctx context.Context, w http.ResponseWriter, provider Provider, question, sessionID string, messages []Message, requestModel, actualModel string) {
 	// 设置SSE相关的响应头
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	resp := makeHeckRequest(question, sessionID, messages, actualModel)
-	if resp.StatusCode != http.StatusOK {
+	pr := ProviderRequest{Ctx: ctx, Question: question, SessionID: sessionID, Messages: messages, Model: actualModel}
+	resp, err := provider.Chat(pr)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		fmt.Printf("Upstream Service Error (%s): %v\n", provider.Name(), err)
 		http.Error(w, "Upstream Service Error", http.StatusInternalServerError)
 		return
 	}
 
 	defer resp.Body.Close()
-	reader := bufio.NewReader(resp.Body)
+	dr := newDeadlineReader(bufio.NewReader(resp.Body), streamIdleTimeout, func() { resp.Body.Close() })
+	defer dr.Stop()
 
-	isAnswering := false
+	decoder := provider.NewDecoder()
 	for {
-		line, err := reader.ReadString('\n')
+		line, err := dr.ReadString('\n')
 		if err != nil {
+			if dr.TimedOut() {
+				sendChunk(w, sessionID, requestModel, Message{}, "timeout")
+				ReportBodyReadResult(resp, false, "idle timeout")
+			} else if err == io.EOF {
+				ReportBodyReadResult(resp, true, "")
+			} else {
+				ReportBodyReadResult(resp, false, err.Error())
+			}
 			break
 		}
 
-		if strings.HasPrefix(line, "data: ") {
-			content := strings.TrimPrefix(line, "data: ")
-			content = strings.TrimSpace(content)
-
-			if content == "[ANSWER_START]" {
-				isAnswering = true
-				chunk := OpenAIResponse{
-					ID:      sessionID,
-					Object:  "chat.completion.chunk",
-					Created: time.Now().Unix(),
-					Model:   requestModel,
-					Choices: []Choice{
-						{
-							Index: 0,
-							Delta: Message{
-								Role: "assistant",
-							},
-						},
-					},
-				}
-				data, _ := json.Marshal(chunk)
-				fmt.Fprintf(w, "data: %s\n\n", data)
-				continue
-			}
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		content := strings.TrimSpace(strings.TrimPrefix(line, "data: "))
+		if content == "" {
+			continue
+		}
 
-			if content == "[ANSWER_DONE]" {
-				chunk := OpenAIResponse{
-					ID:      sessionID,
-					Object:  "chat.completion.chunk",
-					Created: time.Now().Unix(),
-					Model:   requestModel,
-					Choices: []Choice{
-						{
-							Index:        0,
-							Delta:        Message{},
-							FinishReason: "stop",
-						},
-					},
-				}
-				data, _ := json.Marshal(chunk)
-				fmt.Fprintf(w, "data: %s\n\n", data)
-				break
+		ev := decoder.Decode(content)
+		switch ev.Kind {
+		case EventReasoningStart:
+			if foldReasoningIntoThink {
+				sendChunk(w, sessionID, requestModel, Message{Role: "assistant", Content: "<think>\n"}, "")
+			} else {
+				sendChunk(w, sessionID, requestModel, Message{Role: "assistant"}, "")
 			}
-
-			if isAnswering && content != "" &&
-				!strings.HasPrefix(content, "[RELATE_Q_START]") &&
-				!strings.HasPrefix(content, "[RELATE_Q_DONE]") {
-				chunk := OpenAIResponse{
-					ID:      sessionID,
-					Object:  "chat.completion.chunk",
-					Created: time.Now().Unix(),
-					Model:   requestModel,
-					Choices: []Choice{
-						{
-							Index: 0,
-							Delta: Message{
-								Content: content,
-							},
-						},
-					},
-				}
-				data, _ := json.Marshal(chunk)
-				fmt.Fprintf(w, "data: %s\n\n", data)
+		case EventReasoningDone:
+			if foldReasoningIntoThink {
+				sendChunk(w, sessionID, requestModel, Message{Content: "\n</think>\n"}, "")
+			}
+		case EventAnswerStart:
+			sendChunk(w, sessionID, requestModel, Message{Role: "assistant"}, "")
+		case EventReasoningDelta:
+			if foldReasoningIntoThink {
+				sendChunk(w, sessionID, requestModel, Message{Content: ev.Content}, "")
+			} else {
+				sendChunk(w, sessionID, requestModel, Message{ReasoningContent: ev.Content}, "")
 			}
+		case EventAnswerDelta:
+			sendChunk(w, sessionID, requestModel, Message{Content: ev.Content}, "")
+		case EventDone:
+			sendChunk(w, sessionID, requestModel, Message{}, "stop")
+			ReportBodyReadResult(resp, true, "")
+			return
 		}
 	}
 }
 
-func handleNormalResponse(w http.ResponseWriter, question, sessionID string, messages []Message, requestModel, actualModel string) {
+func handleNormalResponse(ctx context.Context, w http.ResponseWriter, provider Provider, question, sessionID string, messages []Message, requestModel, actualModel string) {
 	w.Header().Set("Content-Type", "application/json")
 
-	resp := makeHeckRequest(question, sessionID, messages, actualModel)
+	pr := ProviderRequest{Ctx: ctx, Question: question, SessionID: sessionID, Messages: messages, Model: actualModel}
+	resp, err := provider.Chat(pr)
+	if err != nil {
+		fmt.Printf("Upstream Service Error (%s): %v\n", provider.Name(), err)
+		http.Error(w, "Upstream Service Error", http.StatusInternalServerError)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("Upstream Service Error (%s): status %d\n", provider.Name(), resp.StatusCode)
+		http.Error(w, "Upstream Service Error", http.StatusInternalServerError)
+		return
+	}
 	scanner := bufio.NewScanner(resp.Body)
 
-	var fullContent strings.Builder
-	isAnswering := false
+	decoder := provider.NewDecoder()
+	var fullContent, fullReasoning strings.Builder
+	done := false
 	for scanner.Scan() {
 		line := scanner.Text()
-		if strings.HasPrefix(line, "data: ") {
-			content := strings.TrimPrefix(line, "data: ")
-			if content == "[ANSWER_START]" {
-				isAnswering = true
-				continue
-			}
-			if content == "[ANSWER_DONE]" {
-				isAnswering = false
-				break
-			}
-			if isAnswering {
-				fullContent.WriteString(content)
-			}
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		content := strings.TrimPrefix(line, "data: ")
+
+		ev := decoder.Decode(content)
+		switch ev.Kind {
+		case EventReasoningDelta:
+			fullReasoning.WriteString(ev.Content)
+		case EventAnswerDelta:
+			fullContent.WriteString(ev.Content)
+		case EventDone:
+			done = true
+		}
+		if done {
+			break
+		}
+	}
+	if done {
+		ReportBodyReadResult(resp, true, "")
+	} else if err := scanner.Err(); err != nil {
+		ReportBodyReadResult(resp, false, err.Error())
+	} else {
+		ReportBodyReadResult(resp, false, "stream ended without completion marker")
+	}
+
+	message := Message{Role: "assistant", Content: fullContent.String()}
+	if foldReasoningIntoThink {
+		if fullReasoning.Len() > 0 {
+			message.Content = "<think>\n" + fullReasoning.String() + "\n</think>\n" + message.Content
 		}
+	} else {
+		message.ReasoningContent = fullReasoning.String()
 	}
 
 	response := OpenAIResponse{
@@ -284,11 +321,8 @@ func handleNormalResponse(w http.ResponseWriter, question, sessionID string, mes
 		Model:   requestModel,
 		Choices: []Choice{
 			{
-				Index: 0,
-				Message: Message{
-					Role:    "assistant",
-					Content: fullContent.String(),
-				},
+				Index:        0,
+				Message:      message,
 				FinishReason: "stop",
 			},
 		},
@@ -296,41 +330,3 @@ func handleNormalResponse(w http.ResponseWriter, question, sessionID string, mes
 
 	json.NewEncoder(w).Encode(response)
 }
-
-func makeHeckRequest(question, sessionID string, messages []Message, actualModel string) *http.Response {
-	url := "https://gateway.aiapilab.com/api/ha/v1/chat"
-
-	var previousQuestion, previousAnswer string
-	messagesLen := len(messages)
-	if messagesLen >= 2 {
-		for i := messagesLen - 2; i >= 0; i-- {
-			if messages[i].Role == "user" {
-				previousQuestion = messages[i].Content
-				if i+1 < messagesLen && messages[i+1].Role == "assistant" {
-					previousAnswer = messages[i+1].Content
-				}
-				break
-			}
-		}
-	}
-
-	requestBody := map[string]interface{}{
-		"model":            actualModel,
-		"question":         question,
-		"language":         "Chinese",
-		"sessionId":        sessionID,
-		"previousQuestion": previousQuestion,
-		"previousAnswer":   previousAnswer,
-	}
-
-	jsonData, _ := json.Marshal(requestBody)
-	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("host", "gateway.aiapilab.com")
-
-	client := &http.Client{}
-	resp, _ := client.Do(req)
-	return resp
-}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Model 对应 OpenAI /v1/models 返回条目的形状
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// ModelList 是 /v1/models 的包装返回体
+type ModelList struct {
+	Object string  `json:"object"`
+	Data   []Model `json:"data"`
+}
+
+// modelsCreated 固定一个启动时间戳，避免每次请求都变
+var modelsCreated = time.Now().Unix()
+
+// listModels 汇总所有已注册 Provider 的模型表；Models() 返回 nil 的纯透传
+// Provider（如 openai）不做别名映射，不出现在列表里。同一个别名被多个 Provider
+// 注册时按注册顺序（而不是 map 遍历顺序）先到先得，保证 owned_by 在多次请求
+// 和重启之间保持一致。
+func listModels() []Model {
+	seen := map[string]bool{}
+	var models []Model
+	for _, p := range registeredProviders() {
+		for alias := range p.Models() {
+			if seen[alias] {
+				continue
+			}
+			seen[alias] = true
+			models = append(models, Model{ID: alias, Object: "model", Created: modelsCreated, OwnedBy: p.Name()})
+		}
+	}
+	return models
+}
+
+func handleListModels(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ModelList{Object: "list", Data: listModels()})
+}
+
+func handleRetrieveModel(w http.ResponseWriter, r *http.Request) {
+	idx := strings.LastIndex(r.URL.Path, "/v1/models/")
+	id := r.URL.Path[idx+len("/v1/models/"):]
+
+	for _, m := range listModels() {
+		if m.ID == id {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(m)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("model '%s' not found", id)})
+}
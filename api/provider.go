@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ProviderRequest 是 Handler 传给具体 Provider 的统一请求结构，Model 字段已经
+// 去掉了前缀（heck/ddg/openai），只保留 Provider 自己认识的模型名。Ctx 贯穿
+// 整个上游调用，客户端断开或请求整体超时都通过它传播。
+type ProviderRequest struct {
+	Ctx       context.Context
+	Question  string
+	SessionID string
+	Messages  []Message
+	Model     string
+}
+
+// Provider 代表挂在同一个 /v1/chat/completions 门面后面的一个上游供应商。
+type Provider interface {
+	// Name 返回供应商标识，对应模型前缀，用于日志和 /v1/models 的 owned_by
+	Name() string
+	// Models 返回“对外别名 -> 上游真实模型名”的映射；返回 nil 表示该 Provider
+	// 不做别名映射，模型名原样透传给上游。
+	Models() map[string]string
+	// Chat 发起一次对话请求，返回上游原始响应，由调用方按 SSE 或整包解析
+	Chat(pr ProviderRequest) (*http.Response, error)
+	// NewDecoder 为一次请求新建一个 ResponseDecoder，把这个 Provider 自己的
+	// 上游流协议翻译成统一的 StreamEvent
+	NewDecoder() ResponseDecoder
+}
+
+var providerRegistry = map[string]Provider{}
+
+// providerOrder 记录 Provider 的注册顺序，/v1/models 等需要稳定遍历顺序的地方
+// 用它代替直接遍历 providerRegistry（map 遍历顺序是随机的）
+var providerOrder []string
+
+// RegisterProvider 注册一个供应商，prefix 对应请求里 "prefix/model" 的前缀部分
+func RegisterProvider(prefix string, p Provider) {
+	if _, exists := providerRegistry[prefix]; !exists {
+		providerOrder = append(providerOrder, prefix)
+	}
+	providerRegistry[prefix] = p
+}
+
+// registeredProviders 按注册顺序返回所有 Provider，顺序稳定，不依赖 map 遍历
+func registeredProviders() []Provider {
+	providers := make([]Provider, 0, len(providerOrder))
+	for _, prefix := range providerOrder {
+		providers = append(providers, providerRegistry[prefix])
+	}
+	return providers
+}
+
+// defaultProviderPrefix 是不带前缀的旧式请求（如 "deepseek"）的兜底供应商，
+// 保持对老客户端的兼容。
+const defaultProviderPrefix = "heck"
+
+// resolveProvider 按 "prefix/model" 选择 Provider 并把别名换成上游模型名；
+// 不带前缀或前缀未注册时回落到 defaultProviderPrefix。
+func resolveProvider(requestModel string) (Provider, string, bool) {
+	prefix := defaultProviderPrefix
+	modelName := requestModel
+	if idx := strings.Index(requestModel, "/"); idx > 0 {
+		if _, ok := providerRegistry[requestModel[:idx]]; ok {
+			prefix = requestModel[:idx]
+			modelName = requestModel[idx+1:]
+		}
+	}
+
+	p, ok := providerRegistry[prefix]
+	if !ok {
+		return nil, "", false
+	}
+
+	models := p.Models()
+	if models == nil {
+		return p, modelName, true
+	}
+	actual, ok := models[modelName]
+	if !ok {
+		return nil, "", false
+	}
+	return p, actual, true
+}
+
+// loadModelTable 从 JSON 配置文件加载“别名 -> 上游模型名”的映射并覆盖默认值；
+// 文件不存在或解析失败时直接使用 defaults，方便零配置起步。
+func loadModelTable(defaults map[string]string, configPath string) map[string]string {
+	if configPath == "" {
+		return defaults
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return defaults
+	}
+	var overrides map[string]string
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return defaults
+	}
+	table := make(map[string]string, len(defaults)+len(overrides))
+	for k, v := range defaults {
+		table[k] = v
+	}
+	for k, v := range overrides {
+		table[k] = v
+	}
+	return table
+}
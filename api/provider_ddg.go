@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// defaultDDGModels 把对外暴露的别名映射成 DuckDuckGo AI Chat 后端认识的模型 ID
+var defaultDDGModels = map[string]string{
+	"gpt-4o-mini":    "gpt-4o-mini",
+	"claude-3-haiku": "claude-3-haiku-20240307",
+	"llama-3.1-70b":  "meta-llama/Llama-3.1-70B-Instruct-Turbo",
+	"mixtral-8x7b":   "mistralai/Mixtral-8x7B-Instruct-v0.1",
+}
+
+var ddgModelMapping = loadModelTable(defaultDDGModels, os.Getenv("DDG_MODELS_FILE"))
+
+const (
+	ddgStatusURL = "https://duckduckgo.com/duckchat/v1/status"
+	ddgChatURL   = "https://duckduckgo.com/duckchat/v1/chat"
+)
+
+// DDGProvider 复刻 duckduckgo.com/duckchat 的两步流程：先拿一次 status 握手换取
+// x-vqd-4，再带着这个 token 发起真正的 chat 请求。握手所需的 Cookie 等请求头
+// 从 HAR 池里轮询取用，避免每次都用同一份抓包导致被封。
+type DDGProvider struct {
+	pool *HarPool
+}
+
+// NewDDGProvider 从 harDir 加载抓包模板构建一个 DDGProvider；目录不存在时
+// 退化为不带握手头的直连尝试。
+func NewDDGProvider(harDir string) *DDGProvider {
+	pool, err := LoadHarPool(harDir)
+	if err != nil {
+		fmt.Printf("ddg: failed to load har pool from %s: %v\n", harDir, err)
+	}
+	return &DDGProvider{pool: pool}
+}
+
+func (p *DDGProvider) Name() string { return "ddg" }
+
+func (p *DDGProvider) Models() map[string]string { return ddgModelMapping }
+
+func (p *DDGProvider) Chat(pr ProviderRequest) (*http.Response, error) {
+	vqd, err := p.handshake(pr.Ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ddg handshake failed: %w", err)
+	}
+
+	conv := NativeMessagesEncoder{}.Encode(truncateMessages(pr.Messages, conversationTokenBudget))
+	messages := make([]map[string]string, 0, len(conv.Messages))
+	for _, m := range conv.Messages {
+		messages = append(messages, map[string]string{"role": m.Role, "content": m.Content})
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"model":    pr.Model,
+		"messages": messages,
+	})
+
+	req, err := http.NewRequestWithContext(pr.Ctx, "POST", ddgChatURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-vqd-4", vqd)
+
+	return http.DefaultClient.Do(req)
+}
+
+func (p *DDGProvider) NewDecoder() ResponseDecoder { return ddgDecoder{} }
+
+// ddgDecoder 解析 duckduckgo.com/duckchat 的流式 chunk（data: {"role":
+// "assistant","message":"..."} ... data: [DONE]），无状态，可以安全地用值
+// 类型表示。
+type ddgDecoder struct{}
+
+func (ddgDecoder) Decode(content string) StreamEvent {
+	if content == "[DONE]" {
+		return StreamEvent{Kind: EventDone}
+	}
+
+	var chunk struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(content), &chunk); err != nil || chunk.Message == "" {
+		return StreamEvent{Kind: EventIgnore}
+	}
+	return StreamEvent{Kind: EventAnswerDelta, Content: chunk.Message}
+}
+
+// handshake 向 status 接口要一个新的 x-vqd-4，优先复用 HAR 池里抓到的请求头
+func (p *DDGProvider) handshake(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", ddgStatusURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("x-vqd-accept", "1")
+
+	entry := p.pool.Next()
+	if entry != nil {
+		for k, v := range entry.Headers {
+			req.Header.Set(k, v)
+		}
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if entry != nil {
+			entry.MarkFailure()
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	vqd := resp.Header.Get("x-vqd-4")
+	if vqd == "" {
+		if entry != nil {
+			entry.MarkFailure()
+		}
+		return "", fmt.Errorf("no x-vqd-4 in handshake response")
+	}
+	if entry != nil {
+		entry.MarkSuccess()
+	}
+	return vqd, nil
+}
+
+func init() {
+	harDir := os.Getenv("HAR_POOL_DIR")
+	if harDir == "" {
+		harDir = "harPool"
+	}
+	RegisterProvider("ddg", NewDDGProvider(harDir))
+}
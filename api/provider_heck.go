@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// defaultHeckModels 是原来硬编码在 main.go 里的那张表，现在只服务于 heck 这一个供应商
+var defaultHeckModels = map[string]string{
+	"deepseek":          "deepseek/deepseek-chat",
+	"gpt-4o-mini":       "openai/gpt-4o-mini",
+	"gemini-flash-1.5":  "google/gemini-flash-1.5",
+	"deepseek-reasoner": "deepseek-reasoner",
+	"minimax-01":        "minimax/minimax-01",
+}
+
+// heckModelMapping 支持通过 HECK_MODELS_FILE 指向的 JSON 文件覆盖/扩展默认表
+var heckModelMapping = loadModelTable(defaultHeckModels, os.Getenv("HECK_MODELS_FILE"))
+
+// HeckProvider 对接 gateway.aiapilab.com 的 heck 网关，是注册到 providerRegistry
+// 里的默认供应商（不带前缀的旧式模型名都会落到这里）。
+type HeckProvider struct{}
+
+func (HeckProvider) Name() string { return "heck" }
+
+func (HeckProvider) Models() map[string]string { return heckModelMapping }
+
+func (HeckProvider) Chat(pr ProviderRequest) (*http.Response, error) {
+	messages := truncateMessages(pr.Messages, conversationTokenBudget)
+	conv := heckConversationEncoder.Encode(messages)
+	return makeHeckRequest(pr.Ctx, pr.Question, pr.SessionID, conv, pr.Model)
+}
+
+func (HeckProvider) NewDecoder() ResponseDecoder { return &heckDecoder{} }
+
+func init() {
+	RegisterProvider("heck", HeckProvider{})
+}
+
+// heckDecoder 把 heck 网关自定义的 [REASONING_START]/[REASONING_DONE]/
+// [ANSWER_START]/[ANSWER_DONE] 标记翻译成统一的 StreamEvent，isReasoning/
+// isAnswering 记录当前处在哪个阶段，所以每次请求都要新建一个。
+type heckDecoder struct {
+	isReasoning bool
+	isAnswering bool
+}
+
+func (d *heckDecoder) Decode(content string) StreamEvent {
+	switch content {
+	case reasoningStartMarker:
+		d.isReasoning = true
+		return StreamEvent{Kind: EventReasoningStart}
+	case reasoningDoneMarker:
+		d.isReasoning = false
+		return StreamEvent{Kind: EventReasoningDone}
+	case "[ANSWER_START]":
+		d.isAnswering = true
+		return StreamEvent{Kind: EventAnswerStart}
+	case "[ANSWER_DONE]":
+		return StreamEvent{Kind: EventDone}
+	}
+
+	if d.isReasoning && content != "" {
+		return StreamEvent{Kind: EventReasoningDelta, Content: content}
+	}
+	if d.isAnswering && content != "" &&
+		!strings.HasPrefix(content, "[RELATE_Q_START]") &&
+		!strings.HasPrefix(content, "[RELATE_Q_DONE]") {
+		return StreamEvent{Kind: EventAnswerDelta, Content: content}
+	}
+	return StreamEvent{Kind: EventIgnore}
+}
+
+// heckConversationEncoder 决定 heck 网关怎么看到历史对话，默认用
+// RenderedPromptEncoder 把完整历史塞进 question 字段；设置
+// HECK_CONVERSATION_ENCODER=pair 可以退回旧版单轮 previousQuestion/previousAnswer 行为。
+var heckConversationEncoder ConversationEncoder = func() ConversationEncoder {
+	if envOr("HECK_CONVERSATION_ENCODER", "rendered") == "pair" {
+		return HeckPairEncoder{}
+	}
+	return NewRenderedPromptEncoder()
+}()
+
+func makeHeckRequest(ctx context.Context, question, sessionID string, conv EncodedConversation, actualModel string) (*http.Response, error) {
+	url := "https://gateway.aiapilab.com/api/ha/v1/chat"
+
+	requestQuestion := question
+	previousQuestion := conv.PreviousQuestion
+	previousAnswer := conv.PreviousAnswer
+	if conv.RenderedPrompt != "" {
+		// 渲染编码器已经把完整历史（含当前问题）拼进了一个字符串里，
+		// previousQuestion/previousAnswer 这两个旧字段留空即可。
+		requestQuestion = conv.RenderedPrompt
+		previousQuestion = ""
+		previousAnswer = ""
+	}
+
+	requestBody := map[string]interface{}{
+		"model":            actualModel,
+		"question":         requestQuestion,
+		"language":         "Chinese",
+		"sessionId":        sessionID,
+		"previousQuestion": previousQuestion,
+		"previousAnswer":   previousAnswer,
+	}
+
+	jsonData, _ := json.Marshal(requestBody)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("host", "gateway.aiapilab.com")
+
+	proxy := heckProxyPool.Next()
+	req = req.WithContext(withProxyEntry(ctx, proxy))
+	resp, err := proxy.client.Do(req)
+	if err != nil {
+		proxy.recordResult(false, err.Error())
+		return nil, err
+	}
+	proxy.recordResult(resp.StatusCode < 300, fmt.Sprintf("status %d", resp.StatusCode))
+	return resp, nil
+}
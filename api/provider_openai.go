@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// OpenAIProvider 透传到任意 OpenAI 兼容的上游（自建中转、官方 API 等）。
+// 地址和密钥通过环境变量配置，模型名原样转发，不做别名映射。
+type OpenAIProvider struct{}
+
+func (OpenAIProvider) Name() string { return "openai" }
+
+func (OpenAIProvider) Models() map[string]string { return nil }
+
+func (OpenAIProvider) Chat(pr ProviderRequest) (*http.Response, error) {
+	baseURL := os.Getenv("OPENAI_BASE_URL")
+	if baseURL == "" {
+		baseURL = "https://api.openai.com"
+	}
+	apiKey := os.Getenv("OPENAI_API_KEY")
+
+	conv := NativeMessagesEncoder{}.Encode(truncateMessages(pr.Messages, conversationTokenBudget))
+	messages := make([]map[string]string, 0, len(conv.Messages))
+	for _, m := range conv.Messages {
+		messages = append(messages, map[string]string{"role": m.Role, "content": m.Content})
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"model":    pr.Model,
+		"messages": messages,
+		"stream":   true,
+	})
+
+	req, err := http.NewRequestWithContext(pr.Ctx, "POST", baseURL+"/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+	}
+
+	return http.DefaultClient.Do(req)
+}
+
+func (OpenAIProvider) NewDecoder() ResponseDecoder { return openAIDecoder{} }
+
+func init() {
+	RegisterProvider("openai", OpenAIProvider{})
+}
+
+// openAIDecoder 解析标准的 OpenAI 流式 chunk（data: {"choices":[{"delta":
+// {...},"finish_reason":...}]} ... data: [DONE]），无状态，可以安全地用值
+// 类型表示。
+type openAIDecoder struct{}
+
+func (openAIDecoder) Decode(content string) StreamEvent {
+	if content == "[DONE]" {
+		return StreamEvent{Kind: EventDone}
+	}
+
+	var chunk struct {
+		Choices []struct {
+			Delta struct {
+				Content          string `json:"content"`
+				ReasoningContent string `json:"reasoning_content"`
+			} `json:"delta"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal([]byte(content), &chunk); err != nil || len(chunk.Choices) == 0 {
+		return StreamEvent{Kind: EventIgnore}
+	}
+
+	choice := chunk.Choices[0]
+	if choice.FinishReason != "" {
+		return StreamEvent{Kind: EventDone}
+	}
+	if choice.Delta.ReasoningContent != "" {
+		return StreamEvent{Kind: EventReasoningDelta, Content: choice.Delta.ReasoningContent}
+	}
+	if choice.Delta.Content != "" {
+		return StreamEvent{Kind: EventAnswerDelta, Content: choice.Delta.Content}
+	}
+	return StreamEvent{Kind: EventIgnore}
+}
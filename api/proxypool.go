@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	proxyWindowSize    = 20
+	proxyMinWindowSize = 5
+	proxyFailRatio     = 0.5
+	proxyCooldown      = 2 * time.Minute
+)
+
+// ProxyStats 是 /debug/proxies 展示的单个代理的健康状况
+type ProxyStats struct {
+	URL         string    `json:"url"`
+	Success     int64     `json:"success"`
+	Fail        int64     `json:"fail"`
+	LastError   string    `json:"last_error,omitempty"`
+	BannedUntil time.Time `json:"banned_until,omitempty"`
+}
+
+// proxyEntry 是池子里的一个出口代理：固定的 http.Client 加上一个滑动窗口，
+// 用最近 proxyWindowSize 次请求的成败算失败率
+type proxyEntry struct {
+	mu          sync.Mutex
+	rawURL      string
+	client      *http.Client
+	window      []bool
+	success     int64
+	fail        int64
+	lastError   string
+	bannedUntil time.Time
+}
+
+// recordResult 更新滑动窗口和累计计数，失败率超过 proxyFailRatio 就封禁 proxyCooldown
+func (e *proxyEntry) recordResult(ok bool, errMsg string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if ok {
+		e.success++
+	} else {
+		e.fail++
+		e.lastError = errMsg
+	}
+
+	e.window = append(e.window, ok)
+	if len(e.window) > proxyWindowSize {
+		e.window = e.window[len(e.window)-proxyWindowSize:]
+	}
+
+	if len(e.window) >= proxyMinWindowSize {
+		fails := 0
+		for _, v := range e.window {
+			if !v {
+				fails++
+			}
+		}
+		if float64(fails)/float64(len(e.window)) > proxyFailRatio {
+			e.bannedUntil = time.Now().Add(proxyCooldown)
+		}
+	}
+}
+
+// proxyEntryCtxKey 是把本次请求实际使用的 proxyEntry 存进 context 的键，
+// 这样调用方读完（或读失败）响应体之后，还能把结果回传给 recordResult
+type proxyEntryCtxKey struct{}
+
+// withProxyEntry 把 e 挂进 ctx，makeHeckRequest 选中代理后调用
+func withProxyEntry(ctx context.Context, e *proxyEntry) context.Context {
+	return context.WithValue(ctx, proxyEntryCtxKey{}, e)
+}
+
+// ReportBodyReadResult 在上游响应体读完或读失败后回调，把"连接建立成功但读取
+// 中途出错"也计入对应代理的滑动窗口；resp 不是走代理池发出的（比如 ddg、
+// openai 供应商）时是空操作。
+func ReportBodyReadResult(resp *http.Response, ok bool, errMsg string) {
+	if resp == nil || resp.Request == nil {
+		return
+	}
+	if e, _ := resp.Request.Context().Value(proxyEntryCtxKey{}).(*proxyEntry); e != nil {
+		e.recordResult(ok, errMsg)
+	}
+}
+
+func newProxyEntry(rawURL string) (*proxyEntry, error) {
+	client := http.DefaultClient
+	if rawURL != "" {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		client = &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(u)}}
+	}
+	return &proxyEntry{rawURL: rawURL, client: client}, nil
+}
+
+// ProxyPool 在多个出口代理间轮询，跳过当前被封禁的代理
+type ProxyPool struct {
+	mu      sync.Mutex
+	entries []*proxyEntry
+	next    uint32
+}
+
+// LoadProxyPool 从 path（每行一个 http:// 或 socks5:// URL，# 开头的行当注释）
+// 加载代理列表，再加上 PROXY_URL 环境变量指定的单个代理。两者都没有时返回
+// 一个只含直连条目的池子，调用方无需特判空池。
+func LoadProxyPool(path string) *ProxyPool {
+	pool := &ProxyPool{}
+
+	if f, err := os.Open(path); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if e, err := newProxyEntry(line); err == nil {
+				pool.entries = append(pool.entries, e)
+			}
+		}
+	}
+
+	if envProxy := os.Getenv("PROXY_URL"); envProxy != "" {
+		if e, err := newProxyEntry(envProxy); err == nil {
+			pool.entries = append(pool.entries, e)
+		}
+	}
+
+	if len(pool.entries) == 0 {
+		direct, _ := newProxyEntry("")
+		pool.entries = append(pool.entries, direct)
+	}
+
+	return pool
+}
+
+// Next 以轮询方式返回下一个未被封禁的代理；全部被封禁时仍退回一个，
+// 避免把暂时的误判变成彻底不可用。
+func (p *ProxyPool) Next() *proxyEntry {
+	p.mu.Lock()
+	entries := p.entries
+	p.mu.Unlock()
+
+	now := time.Now()
+	n := len(entries)
+	for i := 0; i < n; i++ {
+		idx := atomic.AddUint32(&p.next, 1) % uint32(n)
+		e := entries[idx]
+		e.mu.Lock()
+		banned := now.Before(e.bannedUntil)
+		e.mu.Unlock()
+		if !banned {
+			return e
+		}
+	}
+	return entries[0]
+}
+
+// redactCredentials 把 URL 里的 user:pass@ 部分换成 ***:***（输出里按 URL 转义为
+// %2A%2A%2A:%2A%2A%2A），/debug/proxies 只展示
+// 代理的地址，不能把配置里的出口凭证回显出去
+func redactCredentials(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	u.User = url.UserPassword("***", "***")
+	return u.String()
+}
+
+// Stats 返回所有代理当前的健康状况，用于 /debug/proxies
+func (p *ProxyPool) Stats() []ProxyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]ProxyStats, 0, len(p.entries))
+	for _, e := range p.entries {
+		e.mu.Lock()
+		stats = append(stats, ProxyStats{
+			URL:         redactCredentials(e.rawURL),
+			Success:     e.success,
+			Fail:        e.fail,
+			LastError:   e.lastError,
+			BannedUntil: e.bannedUntil,
+		})
+		e.mu.Unlock()
+	}
+	return stats
+}
+
+// heckProxyPool 是 heck 网关调用所用的代理池，文件路径可通过 PROXY_FILE 配置
+var heckProxyPool = LoadProxyPool(envOr("PROXY_FILE", "proxies.txt"))
+
+func handleDebugProxies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(heckProxyPool.Stats())
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// 上游用这对标记包裹 deepseek-reasoner 这类模型吐出的思维链，和包裹最终
+// 答案的 [ANSWER_START]/[ANSWER_DONE] 是并列的一对
+const (
+	reasoningStartMarker = "[REASONING_START]"
+	reasoningDoneMarker  = "[REASONING_DONE]"
+)
+
+// foldReasoningIntoThink 为 true 时把 reasoning_content 折叠进 content 字段里的
+// <think>...</think> 标签，供只认识 content、不认识 reasoning_content 扩展字段
+// 的客户端使用。通过 FOLD_REASONING_THINK_TAGS=true 开启，默认关闭。
+var foldReasoningIntoThink = envOr("FOLD_REASONING_THINK_TAGS", "false") == "true"
+
+// sendChunk 把一个 SSE chunk 写给客户端，streaming 响应里的每条分支都复用它
+func sendChunk(w http.ResponseWriter, sessionID, requestModel string, delta Message, finishReason string) {
+	chunk := OpenAIResponse{
+		ID:      sessionID,
+		Object:  "chat.completion.chunk",
+		Created: time.Now().Unix(),
+		Model:   requestModel,
+		Choices: []Choice{
+			{
+				Index:        0,
+				Delta:        delta,
+				FinishReason: finishReason,
+			},
+		},
+	}
+	data, _ := json.Marshal(chunk)
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}